@@ -666,9 +666,12 @@ package common
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"io/ioutil"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/pkg/errors"
 	"fmt"
@@ -681,9 +684,32 @@ type HttpClient interface {
 type VirgilHttpClient struct {
 	Client  HttpClient
 	Address string
+	// Limiter throttles outgoing requests and absorbs 429 responses. Requests are not limited if it is nil
+	Limiter *RateLimiter
+	// MaxRetries is how many times a request rejected with 429 is repeated after honouring Retry-After
+	MaxRetries int
+}
+
+// RateLimitKeyer is implemented by payloads which are rate limited separately, e.g. per client ID
+type RateLimitKeyer interface {
+	RateLimitKey() string
+}
+
+// RateLimitError is returned when the server keeps throttling a request after all retries
+type RateLimitError struct {
+	RetryAfter time.Duration
+	Body       string
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("rate limit exceeded, retry after %s: %s", e.RetryAfter, e.Body)
 }
 
 func (vc *VirgilHttpClient) Send(method string, url string, payload interface{}, respObj interface{}) (headers http.Header, err error) {
+	return vc.SendContext(context.Background(), method, url, payload, respObj)
+}
+
+func (vc *VirgilHttpClient) SendContext(ctx context.Context, method string, url string, payload interface{}, respObj interface{}) (headers http.Header, err error) {
 	var body []byte
 	if payload != nil {
 		body, err = json.Marshal(payload)
@@ -691,10 +717,45 @@ func (vc *VirgilHttpClient) Send(method string, url string, payload interface{},
 			return nil, errors.Wrap(err, "VirgilHttpClient.Send: marshal payload")
 		}
 	}
+
+	var key string
+	if k, ok := payload.(RateLimitKeyer); ok {
+		key = k.RateLimitKey()
+	}
+
+	for attempt := 0; ; attempt++ {
+		if vc.Limiter != nil {
+			if err = vc.Limiter.Wait(ctx, key); err != nil {
+				return nil, errors.Wrap(err, "VirgilHttpClient.Send: wait for rate limiter")
+			}
+		}
+
+		headers, err = vc.send(ctx, method, url, body, respObj)
+
+		rlErr, ok := err.(*RateLimitError)
+		if !ok || attempt >= vc.MaxRetries {
+			return headers, err
+		}
+
+		if vc.Limiter != nil {
+			vc.Limiter.Backoff(key, rlErr.RetryAfter)
+			continue
+		}
+
+		select {
+		case <-time.After(rlErr.RetryAfter):
+		case <-ctx.Done():
+			return nil, errors.Wrap(ctx.Err(), "VirgilHttpClient.Send: wait for retry")
+		}
+	}
+}
+
+func (vc *VirgilHttpClient) send(ctx context.Context, method string, url string, body []byte, respObj interface{}) (headers http.Header, err error) {
 	req, err := http.NewRequest(method, vc.Address+url, bytes.NewReader(body))
 	if err != nil {
 		return nil, errors.Wrap(err, "VirgilHttpClient.Send: new request")
 	}
+	req = req.WithContext(ctx)
 
 	client := vc.getHttpClient()
 	resp, err := client.Do(req)
@@ -723,9 +784,43 @@ func (vc *VirgilHttpClient) Send(method string, url string, payload interface{},
 		return nil, errors.Wrap(err, "VirgilHttpClient.Send: read response body")
 	}
 
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return nil, &RateLimitError{
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+			Body:       string(respBody),
+		}
+	}
+
 	return nil, errors.New(fmt.Sprintf("%s\n",string(respBody)))
 }
 
+// parseRetryAfter accepts both forms of the Retry-After header: delay in seconds and HTTP date.
+// Delays longer than maxRetryAfter are cut to it
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if seconds, err := strconv.ParseUint(value, 10, 64); err == nil || isRangeError(err) {
+		if err != nil || seconds > uint64(maxRetryAfter/time.Second) {
+			return maxRetryAfter
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		d := date.Sub(now)
+		switch {
+		case d <= 0:
+			return 0
+		case d > maxRetryAfter:
+			return maxRetryAfter
+		}
+		return d
+	}
+	return defaultRetryAfter
+}
+
+func isRangeError(err error) bool {
+	numErr, ok := err.(*strconv.NumError)
+	return ok && numErr.Err == strconv.ErrRange
+}
+
 func (vc *VirgilHttpClient) getHttpClient() HttpClient {
 	if vc.Client != nil {
 		return vc.Client
@@ -665,20 +665,20 @@
 package cmd
 
 import (
-	"github.com/VirgilSecurity/pythia-lib-go"
+	"gopkg.in/virgil-pythia-client.v0/protocol"
 	"gopkg.in/virgil-pythia-client.v0/client"
 	"gopkg.in/urfave/cli.v2"
 	"os"
 	"io/ioutil"
-	"encoding/hex"
-	"crypto/subtle"
+	"context"
 )
 
-func Check(client *common.VirgilHttpClient, pythia *pythia.Pythia) *cli.Command {
+func Check(client *common.VirgilHttpClient, pythia protocol.Crypto) *cli.Command {
 	return &cli.Command{
 		Name:      "check",
 		Aliases:   []string{"c"},
 		ArgsUsage:     "username password < protected-password",
+		Flags:     streamFlags(),
 		Action: func(context *cli.Context) error {
 			return checkFunc(context, client, pythia)
 		},
@@ -686,23 +686,33 @@ func Check(client *common.VirgilHttpClient, pythia *pythia.Pythia) *cli.Command
 }
 
 
-func checkFunc (c *cli.Context, client *common.VirgilHttpClient, pythia *pythia.Pythia) error {
-	deblinded, err := RequestEval(c, client, pythia)
+func checkFunc (c *cli.Context, client *common.VirgilHttpClient, pythia protocol.Crypto) error {
+	if c.Bool("stream") {
+		return serveStream(c, newProtocol(c, client, pythia), streamCheck)
+	}
+	if c.Args().Len() != 2 {
+		return cli.Exit("invalid number of arguments", 1)
+	}
+	read, err := ioutil.ReadAll(os.Stdin)
 	if err != nil{
 		return cli.Exit(err, 1)
 	}
-	read, err := ioutil.ReadAll(os.Stdin)
+	record, err := protocol.ParseRecord(string(read))
 	if err != nil{
 		return cli.Exit(err, 1)
 	}
 
-	deblindedHex := hex.EncodeToString(deblinded)
+	p := newProtocol(c, client, pythia)
+	p.Trace = traceStderr
 
-	if subtle.ConstantTimeCompare(read, []byte(deblindedHex)) != 1{
+	ok, err := p.Check(context.Background(), c.Args().First(), c.Args().Get(1), record, purposeOption(c))
+	if err != nil{
+		return cli.Exit(err, 1)
+	}
+
+	if !ok {
 		return cli.Exit("Password does not match", 1)
 	} else{
 		return cli.Exit("Password match", 0)
 	}
-
-	return nil
 }
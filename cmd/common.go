@@ -665,48 +665,122 @@
 package cmd
 
 import (
-	"github.com/VirgilSecurity/pythia-lib-go"
 	"gopkg.in/urfave/cli.v2"
 	"fmt"
 	"os"
+	"context"
+	"database/sql"
 	"encoding/hex"
+	"gopkg.in/virgil-pythia-client.v0/auth"
 	"gopkg.in/virgil-pythia-client.v0/client"
+	"gopkg.in/virgil-pythia-client.v0/policy"
+	"gopkg.in/virgil-pythia-client.v0/protocol"
+	"gopkg.in/virgil-pythia-client.v0/store"
 	"github.com/pkg/errors"
 )
 
-func RequestEval (c *cli.Context, client *common.VirgilHttpClient, pythia *pythia.Pythia) ([]byte, error) {
+func RequestEval (c *cli.Context, client *common.VirgilHttpClient, pythia protocol.Crypto) ([]byte, error) {
 	if c.Args().Len() != 2 {
 		return nil, errors.New("invalid number of arguments")
 	}
 
-	pass := []byte(c.Args().Get(1))
+	p := newProtocol(c, client, pythia)
+	p.Trace = traceStderr
 
-	blinded, secret, err := pythia.Blind(pass)
-	fmt.Fprintf(os.Stderr, "blinded password:     %s...\n", hex.EncodeToString(blinded)[:32])
-
-	if err != nil{
+	record, err := p.Protect(context.Background(), c.Args().First(), c.Args().Get(1), purposeOption(c))
+	if err != nil {
 		return nil, err
 	}
+	return record.Value, nil
+}
+
+// purposeOption applies the global purpose flag
+func purposeOption(c *cli.Context) protocol.Option {
+	return protocol.WithPurpose(c.String("purpose"))
+}
 
-	req := &EvalRequest{
-		T: []byte(c.Args().First()),
-		X: blinded,
-		W: []byte(c.String("clientId")),
+// newProtocol evaluates on the Pythia service, or locally if a local secret is configured
+func newProtocol(c *cli.Context, client *common.VirgilHttpClient, pythia protocol.Crypto) *protocol.Protocol {
+	var evaluator protocol.Evaluator = &protocol.RemoteEvaluator{Client: client}
+	if secret := c.String("local-secret"); secret != "" {
+		evaluator = &protocol.LocalEvaluator{
+			Crypto:      pythia,
+			Secret:      []byte(secret),
+			ScopeSecret: []byte(c.String("local-scope-secret")),
+		}
 	}
 
-	var resp *EvalResponse
+	p := &protocol.Protocol{
+		Crypto:     pythia,
+		Evaluator:  evaluator,
+		ClientID:   c.String("clientId"),
+		KeyVersion: c.Int("key-version"),
+		TweakMode:  c.String("tweak"),
+		TenantKey:  []byte(c.String("tenant-key")),
 
-	_, err = client.Send("POST","/api/v1/eval", req, &resp)
+		UsernameProfile: c.String("username-profile"),
+		PasswordProfile: c.String("password-profile"),
+		CompactKey:      []byte(c.String("compact-key")),
+	}
+	if path := c.String("policy"); path != "" {
+		p.Policy = policy.NewFile(path)
+	}
+	return p
+}
 
-	if err != nil{
-		return nil, err
+// newService configures an account service over the store
+func newService(c *cli.Context, client *common.VirgilHttpClient, pythia protocol.Crypto, s store.Store) *auth.Service {
+	svc := auth.NewService(newProtocol(c, client, pythia), s)
+	svc.HistoryDepth = c.Int("password-history")
+	svc.Purpose = c.String("purpose")
+	svc.Honeywords = c.Int("honeywords")
+	if path := c.String("honeychecker"); path != "" {
+		svc.Honeychecker = auth.NewFileHoneychecker(path)
 	}
+	if c.Int("lockout-threshold") > 0 || c.Duration("lockout-delay") > 0 {
+		svc.Lockout = &auth.Lockout{
+			Threshold: c.Int("lockout-threshold"),
+			Free:      c.Int("lockout-free"),
+			Delay:     c.Duration("lockout-delay"),
+			MaxDelay:  c.Duration("lockout-max-delay"),
+		}
+	}
+	return svc
+}
 
-	fmt.Fprintf(os.Stderr, "response from server: %s...\n", hex.EncodeToString(resp.Y)[:32])
+// OpenStore opens the record store selected by the global store flags.
+// The SQL drivers are linked into the binary by main
+func OpenStore(c *cli.Context) (store.Store, func() error, error) {
+	driver := c.String("store-driver")
+	if driver == "" {
+		return store.NewFileStore(c.String("store")), func() error { return nil }, nil
+	}
 
-	deblinded, err := pythia.Deblind(resp.Y, secret)
+	var dialect string
+	switch driver {
+	case "sqlite3":
+		dialect = store.DialectSQLite
+	case "postgres":
+		dialect = store.DialectPostgres
+	case "mysql":
+		dialect = store.DialectMySQL
+	default:
+		return nil, nil, errors.Errorf("unsupported store driver %q, use sqlite3, postgres or mysql", driver)
+	}
 
-	fmt.Fprintf(os.Stderr, "protected password:   %s...\n", hex.EncodeToString(deblinded)[:32])
+	db, err := sql.Open(driver, c.String("store-dsn"))
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "open store")
+	}
+	s := store.NewSQLStore(db)
+	s.Dialect = dialect
+	if err = s.CreateTable(context.Background()); err != nil {
+		db.Close()
+		return nil, nil, err
+	}
+	return s, db.Close, nil
+}
 
-	return deblinded, err
+func traceStderr(stage string, value []byte) {
+	fmt.Fprintf(os.Stderr, "%-21s %s...\n", stage+":", hex.EncodeToString(value)[:32])
 }
@@ -667,34 +667,28 @@ package cmd
 import (
 	"gopkg.in/urfave/cli.v2"
 	"gopkg.in/virgil-pythia-client.v0/client"
-	"github.com/VirgilSecurity/pythia-lib-go"
+	"gopkg.in/virgil-pythia-client.v0/protocol"
 	"fmt"
+	"context"
 	"encoding/hex"
 )
 
-type EvalRequest struct {
-	W []byte `json:"w"`
-	T []byte `json:"t"`
-	X []byte `json:"x"`
-}
+type EvalRequest = protocol.EvalRequest
 
-type ProofResponse struct {
-	P []byte `json:"p"`
-	C []byte `json:"c"`
-	U []byte `json:"u"`
-}
+type ProofResponse = protocol.ProofResponse
 
-type EvalResponse struct {
-	Y     []byte         `json:"y"`
-	Proof *ProofResponse `json:"proof,omitempty"`
-}
+type EvalResponse = protocol.EvalResponse
 
 
-func Protect(client *common.VirgilHttpClient, pythia *pythia.Pythia) *cli.Command {
+func Protect(client *common.VirgilHttpClient, pythia protocol.Crypto) *cli.Command {
 	return &cli.Command{
 		Name:      "protect",
 		Aliases:   []string{"p"},
 		ArgsUsage:     "username password > protected-password",
+		Flags: append(streamFlags(), &cli.BoolFlag{
+			Name:  "record",
+			Usage: "print the full $pythia$ record even when the bare hex value parses back the same",
+		}),
 		Action: func(context *cli.Context) error {
 			return protectFunc(context, client, pythia)
 		},
@@ -702,12 +696,27 @@ func Protect(client *common.VirgilHttpClient, pythia *pythia.Pythia) *cli.Comman
 }
 
 
-func protectFunc (c *cli.Context, client *common.VirgilHttpClient, pythia *pythia.Pythia) error {
-	deblinded, err := RequestEval(c,client, pythia)
+func protectFunc (c *cli.Context, client *common.VirgilHttpClient, pythia protocol.Crypto) error {
+	if c.Bool("stream") {
+		return serveStream(c, newProtocol(c, client, pythia), streamProtect)
+	}
+	if c.Args().Len() != 2 {
+		return cli.Exit("invalid number of arguments", 1)
+	}
+
+	p := newProtocol(c, client, pythia)
+	p.Trace = traceStderr
+
+	record, err := p.Protect(context.Background(), c.Args().First(), c.Args().Get(1), purposeOption(c))
 	if err != nil{
 		return cli.Exit(err, 1)
 	}
-	fmt.Print(hex.EncodeToString(deblinded))
+	// the bare value loses the parameters of records made with non-default ones
+	if c.Bool("record") || !record.Bare() {
+		fmt.Print(record)
+	} else {
+		fmt.Print(hex.EncodeToString(record.Value))
+	}
 
 	return nil
 }
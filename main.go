@@ -700,16 +700,22 @@ package main
  *
  */
 import (
-	"github.com/VirgilSecurity/pythia-lib-go"
 	"gopkg.in/virgil-pythia-client.v0/client"
 	"gopkg.in/virgil-pythia-client.v0/cmd"
+	"gopkg.in/virgil-pythia-client.v0/protocol"
 	"gopkg.in/urfave/cli.v2"
 	"os"
+	"time"
+
+	// drivers of --store-driver
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
 )
 
 func main() {
 
-	p := pythia.New()
+	p := protocol.NewScheduler(protocol.NewCrypto(), 0)
 
 	client := &common.VirgilHttpClient{
 		Address:"https://pythia.virgilsecurity.com",
@@ -717,6 +723,23 @@ func main() {
 
 	protectCmd := cmd.Protect(client, p)
 	checkCmd := cmd.Check(client, p)
+	benchCmd := cmd.Bench(client, p)
+	selfTestCmd := cmd.SelfTest(p)
+	enrollCmd := cmd.Enroll(client, p)
+	verifyCmd := cmd.Verify(client, p)
+	authdCmd := cmd.Authd(client, p)
+	htpasswdCmd := cmd.Htpasswd(client, p)
+	changeCmd := cmd.Change(client, p)
+	recoveryCodesCmd := cmd.RecoveryCodes(client, p)
+	breachFilterCmd := cmd.BreachFilter()
+	lockoutCmd := cmd.Lockout(client, p)
+	wrapLegacyCmd := cmd.WrapLegacy(client, p)
+	importCmd := cmd.Import(client, p)
+	inspectCmd := cmd.Inspect()
+	exportCmd := cmd.Export()
+	importRecordsCmd := cmd.ImportRecords()
+	exportKeygenCmd := cmd.ExportKeygen()
+	updateCmd := cmd.Update(client, p)
 	app := &cli.App{
 		Flags: []cli.Flag{
 			&cli.StringFlag{
@@ -726,10 +749,173 @@ func main() {
 				Usage:   "Pythia client (app) Id",
 				EnvVars: []string{"CLIENT_ID"},
 			},
+			&cli.Float64Flag{
+				Name:    "rate",
+				Value:   0,
+				Usage:   "max eval requests per second per client Id, 0 means unlimited",
+				EnvVars: []string{"PYTHIA_RATE"},
+			},
+			&cli.IntFlag{
+				Name:    "burst",
+				Value:   1,
+				Usage:   "max eval requests sent at once before rate limiting applies",
+				EnvVars: []string{"PYTHIA_BURST"},
+			},
+			&cli.StringSliceFlag{
+				Name:    "client-rate",
+				Usage:   "rate limit of one client Id as clientId=rate[:burst], overrides --rate and --burst for it",
+				EnvVars: []string{"PYTHIA_CLIENT_RATE"},
+			},
+			&cli.IntFlag{
+				Name:    "retries",
+				Value:   3,
+				Usage:   "how many times a throttled (429) request is retried",
+				EnvVars: []string{"PYTHIA_RETRIES"},
+			},
+			&cli.IntFlag{
+				Name:    "max-crypto",
+				Value:   0,
+				Usage:   "max concurrent native crypto calls, 0 means GOMAXPROCS",
+				EnvVars: []string{"PYTHIA_MAX_CRYPTO"},
+			},
+			&cli.IntFlag{
+				Name:    "key-version",
+				Value:   1,
+				Usage:   "version of the Pythia key recorded in protected passwords",
+				EnvVars: []string{"PYTHIA_KEY_VERSION"},
+			},
+			&cli.StringFlag{
+				Name:    "store",
+				Value:   "records.json",
+				Usage:   "file with records of enrolled users",
+				EnvVars: []string{"PYTHIA_STORE"},
+			},
+			&cli.StringFlag{
+				Name:    "store-driver",
+				Usage:   "keep records in a database instead of the file: sqlite3, postgres or mysql",
+				EnvVars: []string{"PYTHIA_STORE_DRIVER"},
+			},
+			&cli.StringFlag{
+				Name:    "store-dsn",
+				Usage:   "data source name of the records database",
+				EnvVars: []string{"PYTHIA_STORE_DSN"},
+			},
+			&cli.StringFlag{
+				Name:    "purpose",
+				Value:   "password",
+				Usage:   "kind of protected secret, e.g. password, pin or security-answer",
+				EnvVars: []string{"PYTHIA_PURPOSE"},
+			},
+			&cli.IntFlag{
+				Name:    "password-history",
+				Value:   0,
+				Usage:   "number of previous passwords which can not be reused on change",
+				EnvVars: []string{"PYTHIA_PASSWORD_HISTORY"},
+			},
+			&cli.StringFlag{
+				Name:    "local-secret",
+				Usage:   "evaluate locally with this Pythia secret instead of the service",
+				EnvVars: []string{"PYTHIA_LOCAL_SECRET"},
+			},
+			&cli.StringFlag{
+				Name:    "local-scope-secret",
+				Usage:   "Pythia scope secret of the local evaluator",
+				EnvVars: []string{"PYTHIA_LOCAL_SCOPE_SECRET"},
+			},
+			&cli.StringFlag{
+				Name:  "tweak",
+				Value: protocol.TweakRaw,
+				Usage: "how new records derive tweaks from usernames: raw or hmac (hides usernames from the service)",
+			},
+			&cli.StringFlag{
+				Name:    "tenant-key",
+				Usage:   "local key of the hmac tweak mode",
+				EnvVars: []string{"PYTHIA_TENANT_KEY"},
+			},
+			&cli.StringFlag{
+				Name:  "username-profile",
+				Value: protocol.NormalizeNone,
+				Usage: "normalization of usernames in new records: none or casefold",
+			},
+			&cli.StringFlag{
+				Name:  "password-profile",
+				Value: protocol.NormalizeNone,
+				Usage: "normalization of passwords in new records: none, nfkc or opaque",
+			},
+			&cli.StringFlag{
+				Name:    "policy",
+				Usage:   "JSON password policy new passwords are screened against",
+				EnvVars: []string{"PYTHIA_POLICY"},
+			},
+			&cli.IntFlag{
+				Name:  "honeywords",
+				Value: 0,
+				Usage: "number of decoy passwords stored with every new password",
+			},
+			&cli.StringFlag{
+				Name:    "honeychecker",
+				Usage:   "file keeping which stored password is real, keep it apart from the store",
+				EnvVars: []string{"PYTHIA_HONEYCHECKER"},
+			},
+			&cli.IntFlag{
+				Name:  "lockout-threshold",
+				Value: 0,
+				Usage: "failed logins which lock an account until it is unlocked, 0 never locks",
+			},
+			&cli.IntFlag{
+				Name:  "lockout-free",
+				Value: 3,
+				Usage: "failed logins before attempts are delayed",
+			},
+			&cli.DurationFlag{
+				Name:  "lockout-delay",
+				Value: 0,
+				Usage: "delay after the first delayed failure, doubled after each next one",
+			},
+			&cli.DurationFlag{
+				Name:  "lockout-max-delay",
+				Value: 15 * time.Minute,
+				Usage: "longest delay between failed logins",
+			},
+			&cli.StringFlag{
+				Name:    "compact-key",
+				Usage:   "store a keyed digest of new records instead of the full value, they can not be updated with a token",
+				EnvVars: []string{"PYTHIA_COMPACT_KEY"},
+			},
+		},
+		Before: func(c *cli.Context) error {
+			client.Limiter = common.NewRateLimiter(c.Float64("rate"), c.Int("burst"))
+			for _, spec := range c.StringSlice("client-rate") {
+				key, limit, err := common.ParseLimit(spec, c.Int("burst"))
+				if err != nil {
+					return cli.Exit(err, 1)
+				}
+				client.Limiter.SetLimit(key, limit.Rate, limit.Burst)
+			}
+			client.MaxRetries = c.Int("retries")
+			p.SetLimit(c.Int("max-crypto"))
+			return nil
 		},
 		Commands: []*cli.Command{
 			protectCmd,
 			checkCmd,
+			benchCmd,
+			selfTestCmd,
+			enrollCmd,
+			verifyCmd,
+			authdCmd,
+			htpasswdCmd,
+			changeCmd,
+			recoveryCodesCmd,
+			breachFilterCmd,
+			lockoutCmd,
+			wrapLegacyCmd,
+			importCmd,
+			inspectCmd,
+			exportCmd,
+			importRecordsCmd,
+			exportKeygenCmd,
+			updateCmd,
 		},
 	}
 	app.Run(os.Args)